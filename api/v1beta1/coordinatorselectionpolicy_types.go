@@ -0,0 +1,66 @@
+/*
+ * coordinatorselectionpolicy_types.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1beta1
+
+// CoordinatorSelectionPolicySpec configures how the operator picks
+// coordinators for a FoundationDBCluster, in place of the built-in
+// storage -> log -> transaction fallback.
+//
+// This is referenced as FoundationDBClusterSpec.CoordinatorSelectionPolicy;
+// a nil value preserves the historical fallback behavior.
+type CoordinatorSelectionPolicySpec struct {
+	// ClassPreference is the ordered list of process classes the operator
+	// should draw coordinator candidates from. Defaults to
+	// storage, log, transaction when empty.
+	// +kubebuilder:validation:Optional
+	ClassPreference []ProcessClass `json:"classPreference,omitempty"`
+
+	// HardLimits caps the number of coordinators that may share a value of a
+	// locality dimension (e.g. "zoneid", "dcid", "k8s-node", "k8s-cluster" --
+	// the same keys FDB reports in a process's locality map). Entries here
+	// override the operator's built-in defaults for the same dimension.
+	// +kubebuilder:validation:Optional
+	HardLimits map[string]int `json:"hardLimits,omitempty"`
+
+	// SoftLimits behaves like HardLimits but is a preference rather than a
+	// requirement, used to break ties between otherwise-equal candidates.
+	// +kubebuilder:validation:Optional
+	SoftLimits map[string]int `json:"softLimits,omitempty"`
+
+	// MinDistinctZones requires the chosen coordinators to span at least this
+	// many distinct values of the "zoneid" locality dimension. Zero disables the
+	// check.
+	// +kubebuilder:validation:Optional
+	MinDistinctZones int `json:"minDistinctZones,omitempty"`
+
+	// ExcludedInstanceIDs lists instance IDs that must never be chosen as
+	// coordinators, beyond the processes FDB itself reports as excluded, e.g.
+	// instances on tainted nodes or whose pods are failing readiness.
+	// +kubebuilder:validation:Optional
+	ExcludedInstanceIDs map[string]bool `json:"excludedInstanceIDs,omitempty"`
+
+	// PreferStableWeight biases coordinator selection toward the current
+	// coordinators to minimize churn. It ranges from 0 (no bias, optimize
+	// purely for spread) to 1 (always prefer the current coordinators when
+	// they remain eligible).
+	// +kubebuilder:validation:Optional
+	PreferStableWeight float64 `json:"preferStableWeight,omitempty"`
+}
@@ -0,0 +1,122 @@
+/*
+ * federatedfoundationdbcluster_types.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// FederatedFoundationDBCluster is the Schema for the federatedfoundationdbclusters
+// API. It represents a single logical FoundationDB cluster whose processes are
+// split across multiple member Kubernetes clusters, each of which is reconciled
+// independently by its own operator instance.
+type FederatedFoundationDBCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FederatedFoundationDBClusterSpec   `json:"spec,omitempty"`
+	Status FederatedFoundationDBClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FederatedFoundationDBClusterList contains a list of FederatedFoundationDBCluster
+// objects.
+type FederatedFoundationDBClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FederatedFoundationDBCluster `json:"items"`
+}
+
+// FederatedFoundationDBClusterSpec describes the member clusters that make up a
+// single federated FoundationDB deployment, along with the coordinator policy
+// that applies across all of them.
+type FederatedFoundationDBClusterSpec struct {
+	// MemberClusters lists the Kubernetes clusters that host a portion of this
+	// FoundationDB deployment. Each member is reconciled against its own
+	// FoundationDBCluster resource through a dedicated kubeconfig.
+	MemberClusters []FoundationDBClusterMember `json:"memberClusters"`
+
+	// CoordinatorPolicy controls how coordinators are distributed across the
+	// member clusters. Of each member's own coordinator selection policy, only
+	// its excluded instances are applied; the rest of that policy is not used
+	// when choosing federated coordinators.
+	// +kubebuilder:validation:Optional
+	CoordinatorPolicy FederatedCoordinatorPolicy `json:"coordinatorPolicy,omitempty"`
+}
+
+// FoundationDBClusterMember identifies a single member cluster that is part of
+// a federated FoundationDB deployment.
+type FoundationDBClusterMember struct {
+	// Name is a short, unique identifier for this member, used as the `cluster`
+	// locality dimension when spreading coordinators.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the member's FoundationDBCluster resource.
+	Namespace string `json:"namespace"`
+
+	// ClusterName is the name of the member's FoundationDBCluster resource.
+	ClusterName string `json:"clusterName"`
+
+	// KubeconfigSecretRef points to a Secret containing the kubeconfig used to
+	// reach the member cluster's API server. A nil value means the member lives
+	// in the same Kubernetes cluster as the federation controller.
+	// +kubebuilder:validation:Optional
+	KubeconfigSecretRef *corev1.SecretReference `json:"kubeconfigSecretRef,omitempty"`
+}
+
+// FederatedCoordinatorPolicy controls how coordinators are spread across
+// member clusters.
+type FederatedCoordinatorPolicy struct {
+	// MinMembersRepresented is the minimum number of distinct member clusters
+	// that must hold at least one coordinator. A value of zero leaves the
+	// spread unconstrained beyond the per-locality hard limits.
+	// +kubebuilder:validation:Optional
+	MinMembersRepresented int `json:"minMembersRepresented,omitempty"`
+}
+
+// FederatedFoundationDBClusterStatus aggregates the observed state reported by
+// every member cluster.
+type FederatedFoundationDBClusterStatus struct {
+	// ConnectionString is the connection string that all member clusters have
+	// converged on.
+	ConnectionString string `json:"connectionString,omitempty"`
+
+	// MemberStatuses reports the status of each member as of the last
+	// coordinator change, keyed by FoundationDBClusterMember.Name. It is not
+	// updated by reconciles that leave the coordinators unchanged.
+	MemberStatuses map[string]FederatedMemberStatus `json:"memberStatuses,omitempty"`
+}
+
+// FederatedMemberStatus is the last observed state of a single member cluster.
+type FederatedMemberStatus struct {
+	// ConnectionString is the connection string last reported by this member.
+	ConnectionString string `json:"connectionString,omitempty"`
+
+	// Reachable is set to true when the federation controller writes a new
+	// connection string to this member. A member that cannot be reached is
+	// not recorded here: the reconcile returns an error instead.
+	Reachable bool `json:"reachable"`
+}
@@ -0,0 +1,33 @@
+/*
+ * foundationdbcluster_types.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1beta1
+
+// FoundationDBClusterSpec adds the coordinator selection policy field to
+// FoundationDBCluster's spec. The rest of FoundationDBClusterSpec lives
+// outside this tree; this file only carries the field this package's
+// coordinator selection code depends on.
+type FoundationDBClusterSpec struct {
+	// CoordinatorSelectionPolicy configures how the operator picks
+	// coordinators for this cluster. A nil value preserves the historical
+	// storage -> log -> transaction fallback.
+	// +kubebuilder:validation:Optional
+	CoordinatorSelectionPolicy *CoordinatorSelectionPolicySpec `json:"coordinatorSelectionPolicy,omitempty"`
+}
@@ -23,13 +23,31 @@ package controllers
 import (
 	ctx "context"
 	"fmt"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	fdbtypes "github.com/FoundationDB/fdb-kubernetes-operator/api/v1beta1"
 )
 
+// coordinatorsInFlightFinalizer guards the window between selecting new
+// coordinators and recording the resulting connection string in status, so a
+// crash or failed status update in that window is never silently lost.
+const coordinatorsInFlightFinalizer = "foundationdb.org/coordinators-in-flight"
+
+// pendingCoordinatorsAnnotation carries the candidate coordinator addresses
+// that coordinatorsInFlightFinalizer is guarding, as a comma-separated list.
+const pendingCoordinatorsAnnotation = "foundationdb.org/pending-coordinators"
+
+// previousCoordinatorsAnnotation carries the coordinator addresses that were
+// live immediately before the pending change, as a comma-separated list. It
+// is captured alongside pendingCoordinatorsAnnotation so a rollback always
+// re-asserts the true prior set, even if cluster.Status.ConnectionString gets
+// synced to a newer value before recovery runs.
+const previousCoordinatorsAnnotation = "foundationdb.org/previous-coordinators"
+
 // ChangeCoordinators provides a reconciliation step for choosing new
 // coordinators.
 type ChangeCoordinators struct{}
@@ -62,6 +80,37 @@ func (c ChangeCoordinators) Reconcile(r *FoundationDBClusterReconciler, context
 		}
 	}
 
+	// A finalizer left over from a previous attempt means we crashed, or
+	// failed to update status, somewhere between selecting coordinators and
+	// recording the result. Reconcile that attempt before doing anything else.
+	if cluster.Annotations[pendingCoordinatorsAnnotation] != "" && !controllerutil.ContainsFinalizer(cluster, coordinatorsInFlightFinalizer) {
+		// A user stripped the finalizer mid-reconcile; put it back so deletion
+		// cannot race the in-flight coordinator change.
+		controllerutil.AddFinalizer(cluster, coordinatorsInFlightFinalizer)
+		err = r.Update(context, cluster)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// A rollback performed while reconciling an in-flight change calls
+	// adminClient.ChangeCoordinators just like the main change below, so it
+	// needs the same lock held before it runs: otherwise it can race a
+	// concurrent coordinator change taken out by another writer of this lock,
+	// e.g. the federated reconciler acting on a member sharing this cluster.
+	hasLock := false
+	if controllerutil.ContainsFinalizer(cluster, coordinatorsInFlightFinalizer) {
+		hasLock, err = r.takeLock(cluster, "changing coordinators")
+		if !hasLock {
+			return false, err
+		}
+
+		done, err := reconcileInFlightCoordinatorChange(r, context, cluster, adminClient)
+		if !done {
+			return false, err
+		}
+	}
+
 	status, err := adminClient.GetStatus()
 	if err != nil {
 		return false, err
@@ -76,9 +125,11 @@ func (c ChangeCoordinators) Reconcile(r *FoundationDBClusterReconciler, context
 		return true, nil
 	}
 
-	hasLock, err := r.takeLock(cluster, "changing coordinators")
 	if !hasLock {
-		return false, err
+		hasLock, err = r.takeLock(cluster, "changing coordinators")
+		if !hasLock {
+			return false, err
+		}
 	}
 
 	if !allAddressesValid {
@@ -90,17 +141,36 @@ func (c ChangeCoordinators) Reconcile(r *FoundationDBClusterReconciler, context
 	log.Info("Changing coordinators", "namespace", cluster.Namespace, "cluster", cluster.Name)
 	r.Recorder.Event(cluster, corev1.EventTypeNormal, "ChangingCoordinators", "Choosing new coordinators")
 
-	coordinators, err := selectCoordinators(cluster, status)
+	controllerutil.AddFinalizer(cluster, coordinatorsInFlightFinalizer)
+	err = r.Update(context, cluster)
 	if err != nil {
 		return false, err
 	}
 
+	selectionResult, err := getCoordinatorSelectionPolicy(cluster).SelectCoordinators(cluster, status)
+	if err != nil {
+		return false, err
+	}
+	coordinators := selectionResult.Coordinators
+
 	coordinatorAddresses := make([]string, len(coordinators))
 	for index, process := range coordinators {
 		coordinatorAddresses[index] = process.Address
 	}
 
 	log.Info("Final coordinators candidates", "namespace", cluster.Namespace, "cluster", cluster.Name, "coordinators", coordinatorAddresses)
+	r.Recorder.Event(cluster, corev1.EventTypeNormal, "CoordinatorSelectionTiers", fmt.Sprintf("Considered process classes in order: %s", strings.Join(selectionResult.TiersConsidered, ", ")))
+
+	if cluster.Annotations == nil {
+		cluster.Annotations = make(map[string]string)
+	}
+	cluster.Annotations[pendingCoordinatorsAnnotation] = strings.Join(coordinatorAddresses, ",")
+	cluster.Annotations[previousCoordinatorsAnnotation] = strings.Join(coordinatorsFromConnectionString(connectionString), ",")
+	err = r.Update(context, cluster)
+	if err != nil {
+		return false, err
+	}
+
 	connectionString, err = adminClient.ChangeCoordinators(coordinatorAddresses)
 	if err != nil {
 		return false, err
@@ -111,7 +181,102 @@ func (c ChangeCoordinators) Reconcile(r *FoundationDBClusterReconciler, context
 		return false, err
 	}
 
-	return true, nil
+	return clearCoordinatorsInFlight(r, context, cluster)
+}
+
+// reconcileInFlightCoordinatorChange recovers from a reconcile that crashed,
+// or failed its status update, between calling adminClient.ChangeCoordinators
+// and recording the result. It compares the live connection string against
+// the pending candidate addresses recorded in pendingCoordinatorsAnnotation:
+// if they match, the change already took effect and we roll forward by
+// finishing the status write; otherwise we roll back by re-asserting the
+// coordinators recorded in previousCoordinatorsAnnotation, which were
+// captured before the change was attempted. cluster.Status.ConnectionString
+// is used only when that annotation is absent: the out-of-date-sync step earlier in Reconcile
+// overwrites it with whatever is live before recovery ever runs, so by the
+// time we get here it may already reflect a partial or clobbered change
+// rather than the true prior set.
+func reconcileInFlightCoordinatorChange(r *FoundationDBClusterReconciler, context ctx.Context, cluster *fdbtypes.FoundationDBCluster, adminClient AdminClient) (bool, error) {
+	pending := cluster.Annotations[pendingCoordinatorsAnnotation]
+	if pending == "" {
+		return clearCoordinatorsInFlight(r, context, cluster)
+	}
+	pendingAddresses := strings.Split(pending, ",")
+
+	connectionString, err := adminClient.GetConnectionString()
+	if err != nil {
+		return false, err
+	}
+
+	if sameCoordinators(connectionString, pendingAddresses) {
+		log.Info("Rolling forward in-flight coordinator change", "namespace", cluster.Namespace, "cluster", cluster.Name, "coordinators", pendingAddresses)
+		cluster.Status.ConnectionString = connectionString
+		err = r.Status().Update(context, cluster)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		previousAddresses := []string{}
+		if previous := cluster.Annotations[previousCoordinatorsAnnotation]; previous != "" {
+			previousAddresses = strings.Split(previous, ",")
+		} else {
+			// No previous set was captured, e.g. the finalizer survived from
+			// before this annotation existed; fall back to whatever status
+			// currently holds rather than refusing to roll back at all.
+			previousAddresses = coordinatorsFromConnectionString(cluster.Status.ConnectionString)
+		}
+		log.Info("Rolling back in-flight coordinator change", "namespace", cluster.Namespace, "cluster", cluster.Name, "coordinators", previousAddresses)
+		_, err = adminClient.ChangeCoordinators(previousAddresses)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return clearCoordinatorsInFlight(r, context, cluster)
+}
+
+// clearCoordinatorsInFlight removes coordinatorsInFlightFinalizer and its
+// associated annotations once the pending coordinator change has either been
+// completed or rolled back.
+func clearCoordinatorsInFlight(r *FoundationDBClusterReconciler, context ctx.Context, cluster *fdbtypes.FoundationDBCluster) (bool, error) {
+	controllerutil.RemoveFinalizer(cluster, coordinatorsInFlightFinalizer)
+	delete(cluster.Annotations, pendingCoordinatorsAnnotation)
+	delete(cluster.Annotations, previousCoordinatorsAnnotation)
+	err := r.Update(context, cluster)
+	return err == nil, err
+}
+
+// coordinatorsFromConnectionString extracts the comma-separated coordinator
+// addresses from an FDB connection string of the form
+// "description:id@address,address,...".
+func coordinatorsFromConnectionString(connectionString string) []string {
+	parts := strings.SplitN(connectionString, "@", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return strings.Split(parts[1], ",")
+}
+
+// sameCoordinators reports whether connectionString's coordinator addresses
+// are the same set as addresses, ignoring order.
+func sameCoordinators(connectionString string, addresses []string) bool {
+	live := coordinatorsFromConnectionString(connectionString)
+	if len(live) != len(addresses) {
+		return false
+	}
+
+	expected := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		expected[address] = true
+	}
+
+	for _, address := range live {
+		if !expected[address] {
+			return false
+		}
+	}
+
+	return true
 }
 
 // RequeueAfter returns the delay before we should run the reconciliation
@@ -140,36 +305,3 @@ func selectCandidates(cluster *fdbtypes.FoundationDBCluster, status *fdbtypes.Fo
 
 	return candidates
 }
-
-func selectCoordinators(cluster *fdbtypes.FoundationDBCluster, status *fdbtypes.FoundationDBStatus) ([]localityInfo, error) {
-	coordinatorCount := cluster.DesiredCoordinatorCount()
-	candidates := make([]localityInfo, 0, len(status.Cluster.Processes))
-	chooseCoordinators := func(candidates []localityInfo) ([]localityInfo, error) {
-		return chooseDistributedProcesses(candidates, coordinatorCount, processSelectionConstraint{
-			HardLimits: getHardLimits(cluster),
-		})
-	}
-
-	// Use all stateful pods if needed, but only storage if possible.
-	candidates = selectCandidates(cluster, status, candidates, fdbtypes.ProcessClassStorage)
-	coordinators, err := chooseCoordinators(candidates)
-	log.Info("Current coordinators added (storage) candidates", "namespace", cluster.Namespace, "cluster", cluster.Name, "coordinators", coordinators)
-
-	if err != nil {
-		// Add in tLogs as candidates
-		candidates = selectCandidates(cluster, status, candidates, fdbtypes.ProcessClassLog)
-		log.Info("Current coordinators added (TLog) candidates", "namespace", cluster.Namespace, "cluster", cluster.Name, "coordinators", coordinators)
-		coordinators, err = chooseCoordinators(candidates)
-		if err != nil {
-			// Add in transaction roles too
-			candidates = selectCandidates(cluster, status, candidates, fdbtypes.ProcessClassTransaction)
-			log.Info("Current coordinators added (transaction) candidates", "namespace", cluster.Namespace, "cluster", cluster.Name, "coordinators", coordinators)
-			coordinators, err = chooseCoordinators(candidates)
-			if err != nil {
-				return candidates, err
-			}
-		}
-	}
-
-	return coordinators, nil
-}
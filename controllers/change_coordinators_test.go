@@ -0,0 +1,50 @@
+/*
+ * change_coordinators_test.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import "testing"
+
+func TestCoordinatorsFromConnectionString(t *testing.T) {
+	addresses := coordinatorsFromConnectionString("test:abcdef@127.0.0.1:4500,127.0.0.2:4500")
+	if len(addresses) != 2 || addresses[0] != "127.0.0.1:4500" || addresses[1] != "127.0.0.2:4500" {
+		t.Fatalf("unexpected addresses: %v", addresses)
+	}
+
+	if coordinatorsFromConnectionString("not-a-connection-string") != nil {
+		t.Fatalf("expected nil for a connection string with no '@'")
+	}
+}
+
+func TestSameCoordinators(t *testing.T) {
+	connectionString := "test:abcdef@127.0.0.1:4500,127.0.0.2:4500"
+
+	if !sameCoordinators(connectionString, []string{"127.0.0.2:4500", "127.0.0.1:4500"}) {
+		t.Fatalf("expected the same coordinator set to match regardless of order")
+	}
+
+	if sameCoordinators(connectionString, []string{"127.0.0.1:4500"}) {
+		t.Fatalf("expected a coordinator subset not to match")
+	}
+
+	if sameCoordinators(connectionString, []string{"127.0.0.1:4500", "127.0.0.3:4500"}) {
+		t.Fatalf("expected a different coordinator set not to match")
+	}
+}
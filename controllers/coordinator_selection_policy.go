@@ -0,0 +1,340 @@
+/*
+ * coordinator_selection_policy.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"math"
+
+	fdbtypes "github.com/FoundationDB/fdb-kubernetes-operator/api/v1beta1"
+)
+
+// coordinatorSelectionZoneKey is the locality dimension used to check the
+// minimum-spread requirement. It matches the key localityInfoForProcess
+// populates from FDB's own locality map, not a human-facing name, so
+// MinDistinctZones actually constrains real fault domains instead of always
+// seeing an empty value.
+const coordinatorSelectionZoneKey = fdbtypes.FDBLocalityZoneIDKey
+
+// CoordinatorSelectionPolicy chooses the set of coordinator candidates for a
+// cluster given its current status. It replaces the previously hard-coded
+// storage -> log -> transaction fallback with a declarative, per-cluster
+// configurable policy.
+type CoordinatorSelectionPolicy interface {
+	// SelectCoordinators returns the chosen coordinators, along with the
+	// process classes that were tried in order to find them.
+	SelectCoordinators(cluster *fdbtypes.FoundationDBCluster, status *fdbtypes.FoundationDBStatus) (CoordinatorSelectionResult, error)
+}
+
+// CoordinatorSelectionResult is the outcome of running a
+// CoordinatorSelectionPolicy.
+type CoordinatorSelectionResult struct {
+	// Coordinators is the chosen set of coordinator candidates.
+	Coordinators []localityInfo
+
+	// TiersConsidered lists, in order, the process classes the policy had to
+	// draw candidates from before it found a set that satisfied every
+	// constraint. A result of ["storage", "log"] means storage processes
+	// alone were not enough and log processes had to be promoted.
+	TiersConsidered []string
+}
+
+// ExclusionPredicate reports whether a candidate must be excluded from
+// coordinator selection for a reason beyond the default Excluded and
+// InstanceIsBeingRemoved checks, e.g. the candidate's node is tainted or its
+// pod is failing readiness.
+type ExclusionPredicate func(cluster *fdbtypes.FoundationDBCluster, candidate localityInfo) bool
+
+// excludeByInstanceID builds an ExclusionPredicate that rejects any candidate
+// whose instance ID appears in excludedIDs. It is the building block for
+// predicates such as "pod is failing readiness" or "node is tainted", where
+// the caller has already computed the affected instance IDs from cluster
+// state outside of the FDB status (e.g. a pod lister).
+func excludeByInstanceID(excludedIDs map[string]bool) ExclusionPredicate {
+	return func(cluster *fdbtypes.FoundationDBCluster, candidate localityInfo) bool {
+		return excludedIDs[candidate.ID]
+	}
+}
+
+// firstFitCoordinatorPolicy is the default CoordinatorSelectionPolicy. For
+// each class in ClassPreference, in order, it adds that class's eligible
+// processes to the candidate pool and asks chooseDistributedProcesses to
+// satisfy the locality constraints from that pool; it stops at the first
+// tier that succeeds.
+type firstFitCoordinatorPolicy struct {
+	// ClassPreference is the ordered list of process classes to draw
+	// candidates from.
+	ClassPreference []fdbtypes.ProcessClass
+
+	// HardLimits caps the number of coordinators per value of a locality
+	// dimension (FDBLocalityZoneIDKey, FDBLocalityDCIDKey, k8s-node,
+	// k8s-cluster, ...); candidate sets that would exceed it are rejected by
+	// chooseDistributedProcesses.
+	HardLimits map[string]int
+
+	// SoftLimits caps the same kind of locality dimension as HardLimits, but
+	// as a preference rather than a requirement: candidates are reordered so
+	// that ones less likely to exceed a soft limit are tried first, breaking
+	// ties between otherwise-equal candidates.
+	SoftLimits map[string]int
+
+	// MinDistinctZones requires the chosen coordinators to span at least this
+	// many distinct values of the FDBLocalityZoneIDKey locality dimension. Zero disables the
+	// check.
+	MinDistinctZones int
+
+	// Exclusions are evaluated against every candidate in addition to the
+	// default Excluded and InstanceIsBeingRemoved checks.
+	Exclusions []ExclusionPredicate
+
+	// PreferStableWeight biases candidate ordering toward processes that are
+	// already coordinators, trading optimal spread for less churn. It ranges
+	// from 0 (no bias) to 1 (always prefer the current coordinators).
+	PreferStableWeight float64
+}
+
+// getCoordinatorSelectionPolicy builds the CoordinatorSelectionPolicy
+// configured on the cluster, falling back to the historical
+// storage -> log -> transaction behavior when the cluster spec does not
+// declare one.
+func getCoordinatorSelectionPolicy(cluster *fdbtypes.FoundationDBCluster) CoordinatorSelectionPolicy {
+	spec := cluster.Spec.CoordinatorSelectionPolicy
+	if spec == nil {
+		return defaultCoordinatorSelectionPolicy(cluster)
+	}
+
+	hardLimits := getHardLimits(cluster)
+	for dimension, limit := range spec.HardLimits {
+		hardLimits[dimension] = limit
+	}
+
+	classPreference := spec.ClassPreference
+	if len(classPreference) == 0 {
+		classPreference = defaultClassPreference()
+	}
+
+	return &firstFitCoordinatorPolicy{
+		ClassPreference:    classPreference,
+		HardLimits:         hardLimits,
+		SoftLimits:         spec.SoftLimits,
+		MinDistinctZones:   spec.MinDistinctZones,
+		Exclusions:         []ExclusionPredicate{excludeByInstanceID(spec.ExcludedInstanceIDs)},
+		PreferStableWeight: spec.PreferStableWeight,
+	}
+}
+
+// defaultCoordinatorSelectionPolicy reproduces the historical, hard-coded
+// coordinator selection behavior: prefer storage processes, fall back to log
+// and then transaction processes, with no minimum spread or stability bias.
+func defaultCoordinatorSelectionPolicy(cluster *fdbtypes.FoundationDBCluster) CoordinatorSelectionPolicy {
+	return &firstFitCoordinatorPolicy{
+		ClassPreference: defaultClassPreference(),
+		HardLimits:      getHardLimits(cluster),
+	}
+}
+
+func defaultClassPreference() []fdbtypes.ProcessClass {
+	return []fdbtypes.ProcessClass{
+		fdbtypes.ProcessClassStorage,
+		fdbtypes.ProcessClassLog,
+		fdbtypes.ProcessClassTransaction,
+	}
+}
+
+// SelectCoordinators implements CoordinatorSelectionPolicy.
+func (p *firstFitCoordinatorPolicy) SelectCoordinators(cluster *fdbtypes.FoundationDBCluster, status *fdbtypes.FoundationDBStatus) (CoordinatorSelectionResult, error) {
+	coordinatorCount := cluster.DesiredCoordinatorCount()
+	candidates := make([]localityInfo, 0, len(status.Cluster.Processes))
+	tiersConsidered := make([]string, 0, len(p.ClassPreference))
+
+	var coordinators []localityInfo
+	var err error
+	for _, class := range p.ClassPreference {
+		candidates = p.selectCandidates(cluster, status, candidates, class)
+		tiersConsidered = append(tiersConsidered, string(class))
+
+		coordinators, err = p.chooseCoordinators(cluster, candidates, coordinatorCount)
+		// A tier that satisfies chooseDistributedProcesses but not the
+		// minimum-spread requirement still isn't good enough: treat it as a
+		// failure of this tier so the loop promotes the next process class
+		// instead of giving up on a cluster that a later tier could satisfy.
+		if err == nil && p.MinDistinctZones > 0 && !hasMinimumSpread(coordinators, coordinatorSelectionZoneKey, p.MinDistinctZones) {
+			err = fmt.Errorf("could not select coordinators spanning at least %d distinct zones", p.MinDistinctZones)
+		}
+		log.Info("Coordinator candidates considered", "namespace", cluster.Namespace, "cluster", cluster.Name, "tier", class, "coordinators", coordinators)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return CoordinatorSelectionResult{}, err
+	}
+
+	return CoordinatorSelectionResult{Coordinators: coordinators, TiersConsidered: tiersConsidered}, nil
+}
+
+// selectCandidates extends the shared selectCandidates helper with the
+// policy's own exclusion predicates.
+func (p *firstFitCoordinatorPolicy) selectCandidates(cluster *fdbtypes.FoundationDBCluster, status *fdbtypes.FoundationDBStatus, candidates []localityInfo, class fdbtypes.ProcessClass) []localityInfo {
+	base := selectCandidates(cluster, status, nil, class)
+	if len(p.Exclusions) == 0 {
+		return append(candidates, base...)
+	}
+
+	for _, candidate := range base {
+		if p.isExcluded(cluster, candidate) {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+func (p *firstFitCoordinatorPolicy) isExcluded(cluster *fdbtypes.FoundationDBCluster, candidate localityInfo) bool {
+	for _, predicate := range p.Exclusions {
+		if predicate(cluster, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseCoordinators runs chooseDistributedProcesses against the candidate
+// pool. Soft limits and PreferStableWeight aren't constraints
+// chooseDistributedProcesses itself understands, so both are applied here as
+// a reordering of the candidate pool: chooseDistributedProcesses fills
+// coordinator slots in candidate order, so a candidate nearer the front is
+// preferred over one further back.
+func (p *firstFitCoordinatorPolicy) chooseCoordinators(cluster *fdbtypes.FoundationDBCluster, candidates []localityInfo, coordinatorCount int) ([]localityInfo, error) {
+	ordered := candidates
+	if len(p.SoftLimits) > 0 {
+		ordered = orderBySoftLimits(ordered, p.SoftLimits)
+	}
+	if p.PreferStableWeight > 0 {
+		ordered = preferCurrentCoordinators(cluster, ordered, p.PreferStableWeight)
+	}
+
+	return chooseDistributedProcesses(ordered, coordinatorCount, processSelectionConstraint{
+		HardLimits: p.HardLimits,
+	})
+}
+
+// orderBySoftLimits greedily reorders candidates so that, at each step, the
+// candidate least likely to push a locality dimension over its soft limit is
+// placed next. This breaks ties between otherwise-equal candidates in favor
+// of spreading load across soft-limited dimensions, without requiring
+// chooseDistributedProcesses itself to know about soft limits.
+func orderBySoftLimits(candidates []localityInfo, softLimits map[string]int) []localityInfo {
+	counts := make(map[string]map[string]int, len(softLimits))
+	for dimension := range softLimits {
+		counts[dimension] = make(map[string]int)
+	}
+
+	remaining := make([]localityInfo, len(candidates))
+	copy(remaining, candidates)
+	ordered := make([]localityInfo, 0, len(candidates))
+
+	for len(remaining) > 0 {
+		bestIndex := 0
+		bestPenalty := softLimitPenalty(remaining[0], softLimits, counts)
+		for i := 1; i < len(remaining); i++ {
+			penalty := softLimitPenalty(remaining[i], softLimits, counts)
+			if penalty < bestPenalty {
+				bestPenalty = penalty
+				bestIndex = i
+			}
+		}
+
+		chosen := remaining[bestIndex]
+		ordered = append(ordered, chosen)
+		for dimension := range softLimits {
+			counts[dimension][chosen.LocalityData[dimension]]++
+		}
+		remaining = append(remaining[:bestIndex], remaining[bestIndex+1:]...)
+	}
+
+	return ordered
+}
+
+// softLimitPenalty scores how much picking candidate next would push its
+// locality values past their soft limits, given how many candidates sharing
+// each value have already been picked.
+func softLimitPenalty(candidate localityInfo, softLimits map[string]int, counts map[string]map[string]int) int {
+	penalty := 0
+	for dimension, limit := range softLimits {
+		count := counts[dimension][candidate.LocalityData[dimension]]
+		if count >= limit {
+			penalty += count - limit + 1
+		}
+	}
+	return penalty
+}
+
+// preferCurrentCoordinators reorders candidates so that a fraction of the
+// processes which are already coordinators are promoted to the front of the
+// list, proportional to weight: weight 1 promotes every current coordinator
+// ahead of every other candidate, weight 0.5 promotes about half of them,
+// and weight 0 leaves the order unchanged.
+func preferCurrentCoordinators(cluster *fdbtypes.FoundationDBCluster, candidates []localityInfo, weight float64) []localityInfo {
+	if weight <= 0 {
+		return candidates
+	}
+
+	currentCoordinators := make(map[string]bool)
+	for _, address := range coordinatorsFromConnectionString(cluster.Status.ConnectionString) {
+		currentCoordinators[address] = true
+	}
+
+	stableCount := 0
+	for _, candidate := range candidates {
+		if currentCoordinators[candidate.Address] {
+			stableCount++
+		}
+	}
+	if stableCount == 0 {
+		return candidates
+	}
+
+	promoteCount := int(math.Round(weight * float64(stableCount)))
+
+	promoted := make([]localityInfo, 0, promoteCount)
+	remainder := make([]localityInfo, 0, len(candidates))
+	for _, candidate := range candidates {
+		if len(promoted) < promoteCount && currentCoordinators[candidate.Address] {
+			promoted = append(promoted, candidate)
+			continue
+		}
+		remainder = append(remainder, candidate)
+	}
+
+	return append(promoted, remainder...)
+}
+
+// hasMinimumSpread reports whether coordinators span at least minDistinct
+// distinct values of the given locality dimension.
+func hasMinimumSpread(coordinators []localityInfo, dimension string, minDistinct int) bool {
+	seen := make(map[string]bool, len(coordinators))
+	for _, coordinator := range coordinators {
+		seen[coordinator.LocalityData[dimension]] = true
+	}
+	return len(seen) >= minDistinct
+}
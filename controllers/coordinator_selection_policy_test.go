@@ -0,0 +1,88 @@
+/*
+ * coordinator_selection_policy_test.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"testing"
+
+	fdbtypes "github.com/FoundationDB/fdb-kubernetes-operator/api/v1beta1"
+)
+
+func TestHasMinimumSpread(t *testing.T) {
+	coordinators := []localityInfo{
+		{Address: "1", LocalityData: map[string]string{"zone": "z1"}},
+		{Address: "2", LocalityData: map[string]string{"zone": "z1"}},
+		{Address: "3", LocalityData: map[string]string{"zone": "z2"}},
+	}
+
+	if hasMinimumSpread(coordinators, "zone", 3) {
+		t.Fatalf("expected only 2 distinct zones to fail a minimum of 3")
+	}
+	if !hasMinimumSpread(coordinators, "zone", 2) {
+		t.Fatalf("expected 2 distinct zones to satisfy a minimum of 2")
+	}
+}
+
+func TestPreferCurrentCoordinatorsIsProportional(t *testing.T) {
+	cluster := &fdbtypes.FoundationDBCluster{
+		Status: fdbtypes.FoundationDBClusterStatus{
+			ConnectionString: "test:abcdef@1,2",
+		},
+	}
+	candidates := []localityInfo{
+		{Address: "a"},
+		{Address: "1"},
+		{Address: "b"},
+		{Address: "2"},
+	}
+
+	unchanged := preferCurrentCoordinators(cluster, candidates, 0)
+	if unchanged[0].Address != "a" {
+		t.Fatalf("expected a weight of 0 to leave the order unchanged, got %v", unchanged)
+	}
+
+	half := preferCurrentCoordinators(cluster, candidates, 0.5)
+	if half[0].Address != "1" {
+		t.Fatalf("expected a weight of 0.5 to promote at least one current coordinator to the front, got %v", half)
+	}
+	if half[1].Address != "a" {
+		t.Fatalf("expected a weight of 0.5 to promote only one of the two current coordinators, got %v", half)
+	}
+
+	full := preferCurrentCoordinators(cluster, candidates, 1)
+	if full[0].Address != "1" || full[1].Address != "2" {
+		t.Fatalf("expected a weight of 1 to promote every current coordinator to the front, got %v", full)
+	}
+}
+
+func TestOrderBySoftLimitsSpreadsAcrossDimension(t *testing.T) {
+	candidates := []localityInfo{
+		{Address: "1", LocalityData: map[string]string{"zone": "z1"}},
+		{Address: "2", LocalityData: map[string]string{"zone": "z1"}},
+		{Address: "3", LocalityData: map[string]string{"zone": "z2"}},
+	}
+
+	ordered := orderBySoftLimits(candidates, map[string]int{"zone": 1})
+
+	if ordered[0].LocalityData["zone"] == ordered[1].LocalityData["zone"] {
+		t.Fatalf("expected the first two picks to come from different zones when the soft limit is 1, got %v", ordered)
+	}
+}
@@ -0,0 +1,379 @@
+/*
+ * federated_change_coordinators.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	ctx "context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	fdbtypes "github.com/FoundationDB/fdb-kubernetes-operator/api/v1beta1"
+)
+
+// federatedCoordinatorLockPrefix prefixes the action description passed to
+// takeLock when running coordinator changes for a federated cluster. It only
+// labels the action; the lock taken is the one takeLock takes for the member
+// cluster it is called with.
+const federatedCoordinatorLockPrefix = "federation/coordinators"
+
+// federatedClusterLocalityKey is the locality dimension that candidates are
+// tagged with to identify which member cluster they belong to, so
+// chooseDistributedProcesses and hasMinimumSpread can reason about spread
+// across member clusters the same way they reason about zones or racks.
+const federatedClusterLocalityKey = "cluster"
+
+// federatedClientCacheFinalizer guards federatedClientCache's entries for a
+// FederatedFoundationDBCluster, so its cached admin clients are closed on
+// deletion instead of leaking for the rest of the operator's lifetime.
+const federatedClientCacheFinalizer = "foundationdb.org/federated-client-cache"
+
+// FederatedChangeCoordinators provides a reconciliation step for choosing new
+// coordinators across all the member clusters of a FederatedFoundationDBCluster.
+type FederatedChangeCoordinators struct{}
+
+// memberClient bundles the pieces we need to talk to one member cluster: a
+// controller-runtime client scoped to its API server and a cached admin
+// client for the FDB cluster it hosts.
+type memberClient struct {
+	member      fdbtypes.FoundationDBClusterMember
+	k8sClient   client.Client
+	cluster     *fdbtypes.FoundationDBCluster
+	adminClient AdminClient
+}
+
+// federatedClientCacheEntry remembers the connection hash an admin client was
+// built from, so a kubeconfig change invalidates the cache instead of being
+// silently ignored. Cached admin clients are intentionally kept open across
+// reconciles for reuse instead of being closed on every Reconcile call; they
+// are only closed when getMemberClient replaces them with a fresh client, or
+// when evictFederatedClientCache closes them on the owning
+// FederatedFoundationDBCluster's deletion.
+type federatedClientCacheEntry struct {
+	hash        string
+	adminClient AdminClient
+}
+
+// federatedClientCache is keyed by owning federated cluster and member,
+// rather than by member alone: two FederatedFoundationDBClusters can
+// reference the same member, and keying by member alone would mean a
+// kubeconfig rotation on one cluster's reconcile closes a client the other
+// cluster is still using concurrently.
+var federatedClientCacheMutex sync.Mutex
+var federatedClientCache = map[string]federatedClientCacheEntry{}
+
+// federatedClientCacheKey returns the federatedClientCache key for a member
+// as reached through the given federated cluster.
+func federatedClientCacheKey(federatedCluster *fdbtypes.FederatedFoundationDBCluster, member fdbtypes.FoundationDBClusterMember) string {
+	return fmt.Sprintf("%s/%s/%s/%s", federatedCluster.Namespace, federatedCluster.Name, member.Namespace, member.ClusterName)
+}
+
+// evictFederatedClientCache closes and removes every cache entry reached
+// through federatedCluster, so its admin clients don't leak once it's
+// deleted.
+func evictFederatedClientCache(federatedCluster *fdbtypes.FederatedFoundationDBCluster) {
+	prefix := fmt.Sprintf("%s/%s/", federatedCluster.Namespace, federatedCluster.Name)
+
+	federatedClientCacheMutex.Lock()
+	defer federatedClientCacheMutex.Unlock()
+
+	for key, entry := range federatedClientCache {
+		if strings.HasPrefix(key, prefix) {
+			entry.adminClient.Close()
+			delete(federatedClientCache, key)
+		}
+	}
+}
+
+// Reconcile runs the reconciler's work.
+func (c FederatedChangeCoordinators) Reconcile(r *FoundationDBClusterReconciler, context ctx.Context, federatedCluster *fdbtypes.FederatedFoundationDBCluster) (bool, error) {
+	if !federatedCluster.DeletionTimestamp.IsZero() {
+		evictFederatedClientCache(federatedCluster)
+		controllerutil.RemoveFinalizer(federatedCluster, federatedClientCacheFinalizer)
+		return true, r.Update(context, federatedCluster)
+	}
+
+	if !controllerutil.ContainsFinalizer(federatedCluster, federatedClientCacheFinalizer) {
+		controllerutil.AddFinalizer(federatedCluster, federatedClientCacheFinalizer)
+		err := r.Update(context, federatedCluster)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if len(federatedCluster.Spec.MemberClusters) == 0 {
+		r.Recorder.Event(federatedCluster, corev1.EventTypeWarning, "NoMemberClusters", "FederatedFoundationDBCluster has no member clusters configured")
+		return false, fmt.Errorf("federated cluster %s has no member clusters configured", federatedCluster.Name)
+	}
+
+	members := make([]memberClient, 0, len(federatedCluster.Spec.MemberClusters))
+	for _, member := range federatedCluster.Spec.MemberClusters {
+		memberClient, err := getMemberClient(r, context, federatedCluster, member)
+		if err != nil {
+			return false, err
+		}
+		members = append(members, memberClient)
+	}
+
+	// takeLock is called with members[0].cluster, so the lock taken is that
+	// member cluster's own lock, the same one ChangeCoordinators takes; the
+	// federated cluster's name only appears in the action description, and no
+	// lock key specific to the federated cluster is used.
+	// Every member is reached through an admin client for the same logical FDB
+	// cluster, so taking the lock through any one member's cluster is enough
+	// to be visible to operators watching the other members.
+	hasLock, err := r.takeLock(members[0].cluster, fmt.Sprintf("%s/%s", federatedCoordinatorLockPrefix, federatedCluster.Name))
+	if !hasLock {
+		return false, err
+	}
+
+	statuses := make([]*fdbtypes.FoundationDBStatus, len(members))
+	for index, member := range members {
+		status, err := member.adminClient.GetStatus()
+		if err != nil {
+			return false, err
+		}
+		statuses[index] = status
+	}
+
+	// Any member's status already describes every process in the federation,
+	// since all members are reconciled against the same underlying FDB
+	// database, so checking validity against members[0] is enough to tell
+	// whether the federation's current coordinators are still good.
+	hasValidCoordinators, allAddressesValid, err := checkCoordinatorValidity(members[0].cluster, statuses[0])
+	if err != nil {
+		return false, err
+	}
+	if hasValidCoordinators {
+		return true, nil
+	}
+	if !allAddressesValid {
+		log.Info("Deferring federated coordinator change", "cluster", federatedCluster.Name)
+		r.Recorder.Event(federatedCluster, corev1.EventTypeNormal, "DeferringCoordinatorChange", "Deferring coordinator change until all processes have consistent address TLS settings")
+		return true, nil
+	}
+
+	coordinatorCount := members[0].cluster.DesiredCoordinatorCount()
+	hardLimits := getFederatedHardLimits(federatedCluster, members[0].cluster, len(members))
+
+	hardLimitFields := make([]string, 0, len(hardLimits))
+	for dimension := range hardLimits {
+		hardLimitFields = append(hardLimitFields, dimension)
+	}
+
+	var coordinators []localityInfo
+	candidates := make([]localityInfo, 0)
+	tiersConsidered := make([]string, 0, len(defaultClassPreference()))
+	for _, class := range defaultClassPreference() {
+		for index, member := range members {
+			memberCandidates := selectCandidatesForMember(member, statuses[index], class)
+			for _, candidate := range memberCandidates {
+				candidates = append(candidates, withClusterLocality(candidate, member.member.Name))
+			}
+		}
+		tiersConsidered = append(tiersConsidered, string(class))
+
+		coordinators, err = chooseDistributedProcesses(candidates, coordinatorCount, processSelectionConstraint{
+			HardLimits: hardLimits,
+			Fields:     hardLimitFields,
+		})
+		log.Info("Federated coordinator candidates considered", "cluster", federatedCluster.Name, "tier", class, "coordinators", coordinators)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if minMembers := federatedCluster.Spec.CoordinatorPolicy.MinMembersRepresented; minMembers > 0 && !hasMinimumSpread(coordinators, federatedClusterLocalityKey, minMembers) {
+		return false, fmt.Errorf("could not select federated coordinators spanning at least %d distinct member clusters", minMembers)
+	}
+
+	log.Info("Coordinator selection tiers considered", "cluster", federatedCluster.Name, "tiers", tiersConsidered)
+
+	coordinatorAddresses := make([]string, len(coordinators))
+	for index, process := range coordinators {
+		coordinatorAddresses[index] = process.Address
+	}
+
+	log.Info("Final federated coordinators candidates", "cluster", federatedCluster.Name, "coordinators", coordinatorAddresses)
+
+	// Any member's admin client is connected to the same logical FDB cluster,
+	// so changing coordinators through one of them is enough.
+	connectionString, err := members[0].adminClient.ChangeCoordinators(coordinatorAddresses)
+	if err != nil {
+		return false, err
+	}
+
+	memberStatuses := map[string]fdbtypes.FederatedMemberStatus{}
+	for _, member := range members {
+		member.cluster.Status.ConnectionString = connectionString
+		err = member.k8sClient.Status().Update(context, member.cluster)
+		if err != nil {
+			return false, err
+		}
+		memberStatuses[member.member.Name] = fdbtypes.FederatedMemberStatus{
+			ConnectionString: connectionString,
+			Reachable:        true,
+		}
+	}
+
+	federatedCluster.Status.ConnectionString = connectionString
+	federatedCluster.Status.MemberStatuses = memberStatuses
+	r.Recorder.Event(federatedCluster, corev1.EventTypeNormal, "UpdatingConnectionString", fmt.Sprintf("Setting federated connection string to %s", connectionString))
+
+	return true, r.Status().Update(context, federatedCluster)
+}
+
+// RequeueAfter returns the delay before we should run the reconciliation
+// again.
+func (c FederatedChangeCoordinators) RequeueAfter() time.Duration {
+	return 0
+}
+
+// getMemberClient returns a cached admin client for the given member, along
+// with the k8s client and FoundationDBCluster object used to reach it,
+// rebuilding the client if the member's kubeconfig has changed.
+func getMemberClient(r *FoundationDBClusterReconciler, context ctx.Context, federatedCluster *fdbtypes.FederatedFoundationDBCluster, member fdbtypes.FoundationDBClusterMember) (memberClient, error) {
+	restConfig, hash, err := getMemberRestConfig(r, context, federatedCluster, member)
+	if err != nil {
+		return memberClient{}, err
+	}
+
+	k8sClient, err := client.New(restConfig, client.Options{})
+	if err != nil {
+		return memberClient{}, err
+	}
+
+	cluster := &fdbtypes.FoundationDBCluster{}
+	err = k8sClient.Get(context, client.ObjectKey{Namespace: member.Namespace, Name: member.ClusterName}, cluster)
+	if err != nil {
+		return memberClient{}, err
+	}
+
+	federatedClientCacheMutex.Lock()
+	defer federatedClientCacheMutex.Unlock()
+
+	cacheKey := federatedClientCacheKey(federatedCluster, member)
+	entry, present := federatedClientCache[cacheKey]
+	if present && entry.hash == hash {
+		return memberClient{member: member, k8sClient: k8sClient, cluster: cluster, adminClient: entry.adminClient}, nil
+	}
+
+	adminClient, err := r.getDatabaseClientProvider().GetAdminClient(cluster, k8sClient)
+	if err != nil {
+		return memberClient{}, err
+	}
+
+	// The member's kubeconfig rotated since the cached client was built; close
+	// the stale client before replacing it so the underlying connection isn't
+	// leaked.
+	if present {
+		entry.adminClient.Close()
+	}
+	federatedClientCache[cacheKey] = federatedClientCacheEntry{hash: hash, adminClient: adminClient}
+
+	return memberClient{member: member, k8sClient: k8sClient, cluster: cluster, adminClient: adminClient}, nil
+}
+
+// getMemberRestConfig builds the REST config used to reach a member cluster,
+// along with a stable hash of the kubeconfig it was built from so callers can
+// detect when a cached client needs to be invalidated.
+func getMemberRestConfig(r *FoundationDBClusterReconciler, context ctx.Context, federatedCluster *fdbtypes.FederatedFoundationDBCluster, member fdbtypes.FoundationDBClusterMember) (*rest.Config, string, error) {
+	if member.KubeconfigSecretRef == nil {
+		config, err := rest.InClusterConfig()
+		return config, "in-cluster", err
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(context, client.ObjectKey{Namespace: member.KubeconfigSecretRef.Namespace, Name: member.KubeconfigSecretRef.Name}, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	kubeconfig, present := secret.Data["kubeconfig"]
+	if !present {
+		return nil, "", fmt.Errorf("secret %s/%s has no kubeconfig key", secret.Namespace, secret.Name)
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hash := sha256.Sum256(kubeconfig)
+	return restConfig, hex.EncodeToString(hash[:]), nil
+}
+
+// selectCandidatesForMember draws class's eligible candidates from member,
+// applying member.cluster.Spec.CoordinatorSelectionPolicy's own exclusions on
+// top of the class-matching filter. Only the exclusions of the member's policy
+// are used here; its class preference, hard and soft limits, minimum zone
+// spread and stability weight do not affect federated selection.
+func selectCandidatesForMember(member memberClient, status *fdbtypes.FoundationDBStatus, class fdbtypes.ProcessClass) []localityInfo {
+	if policy, ok := getCoordinatorSelectionPolicy(member.cluster).(*firstFitCoordinatorPolicy); ok {
+		return policy.selectCandidates(member.cluster, status, nil, class)
+	}
+	return selectCandidates(member.cluster, status, nil, class)
+}
+
+// withClusterLocality returns a copy of info tagged with the member cluster's
+// name under the federatedClusterLocalityKey locality dimension, so
+// chooseDistributedProcesses can spread coordinators across member clusters
+// the same way it spreads them across zones or racks.
+func withClusterLocality(info localityInfo, clusterName string) localityInfo {
+	locality := make(map[string]string, len(info.LocalityData)+1)
+	for key, value := range info.LocalityData {
+		locality[key] = value
+	}
+	locality[federatedClusterLocalityKey] = clusterName
+	info.LocalityData = locality
+	return info
+}
+
+// getFederatedHardLimits extends the built-in hard limits that getHardLimits
+// returns for cluster (not the HardLimits of its coordinator selection policy)
+// with a limit on
+// how many coordinators any single member cluster may hold, so the 3/5/7/9
+// coordinators are spread across member clusters rather than concentrated in
+// one.
+func getFederatedHardLimits(federatedCluster *fdbtypes.FederatedFoundationDBCluster, cluster *fdbtypes.FoundationDBCluster, memberCount int) map[string]int {
+	limits := getHardLimits(cluster)
+	if memberCount == 0 {
+		return limits
+	}
+
+	coordinatorCount := cluster.DesiredCoordinatorCount()
+	perMember := (coordinatorCount + memberCount - 1) / memberCount
+	limits[federatedClusterLocalityKey] = perMember
+
+	return limits
+}
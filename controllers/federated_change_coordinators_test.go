@@ -0,0 +1,45 @@
+/*
+ * federated_change_coordinators_test.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import "testing"
+
+func TestWithClusterLocality(t *testing.T) {
+	info := localityInfo{
+		ID:      "storage-1",
+		Address: "127.0.0.1:4500",
+		LocalityData: map[string]string{
+			"zone": "z1",
+		},
+	}
+
+	tagged := withClusterLocality(info, "dc-east")
+
+	if tagged.LocalityData["cluster"] != "dc-east" {
+		t.Fatalf("expected cluster locality to be set, got %v", tagged.LocalityData)
+	}
+	if tagged.LocalityData["zone"] != "z1" {
+		t.Fatalf("expected existing locality data to be preserved, got %v", tagged.LocalityData)
+	}
+	if info.LocalityData["cluster"] != "" {
+		t.Fatalf("expected withClusterLocality not to mutate its input, got %v", info.LocalityData)
+	}
+}
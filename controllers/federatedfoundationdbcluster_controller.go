@@ -0,0 +1,84 @@
+/*
+ * federatedfoundationdbcluster_controller.go
+ *
+ * This source file is part of the FoundationDB open source project
+ *
+ * Copyright 2019 Apple Inc. and the FoundationDB project authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	ctx "context"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fdbtypes "github.com/FoundationDB/fdb-kubernetes-operator/api/v1beta1"
+)
+
+// FederatedFoundationDBClusterReconciler reconciles a FederatedFoundationDBCluster object.
+type FederatedFoundationDBClusterReconciler struct {
+	*FoundationDBClusterReconciler
+	subReconcilers []federatedSubReconciler
+}
+
+// federatedSubReconciler mirrors subReconciler's (Reconcile, RequeueAfter)
+// shape, scoped to a FederatedFoundationDBCluster rather than a single
+// member's FoundationDBCluster.
+type federatedSubReconciler interface {
+	Reconcile(r *FoundationDBClusterReconciler, context ctx.Context, cluster *fdbtypes.FederatedFoundationDBCluster) (bool, error)
+	RequeueAfter() time.Duration
+}
+
+// NewFederatedFoundationDBClusterReconciler returns a reconciler wired with
+// the default federated sub-reconcilers.
+func NewFederatedFoundationDBClusterReconciler(r *FoundationDBClusterReconciler) *FederatedFoundationDBClusterReconciler {
+	return &FederatedFoundationDBClusterReconciler{
+		FoundationDBClusterReconciler: r,
+		subReconcilers: []federatedSubReconciler{
+			FederatedChangeCoordinators{},
+		},
+	}
+}
+
+// Reconcile runs the reconciler's work for a single FederatedFoundationDBCluster.
+func (r *FederatedFoundationDBClusterReconciler) Reconcile(context ctx.Context, request ctrl.Request) (ctrl.Result, error) {
+	federatedCluster := &fdbtypes.FederatedFoundationDBCluster{}
+	err := r.Get(context, request.NamespacedName, federatedCluster)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	for _, subReconciler := range r.subReconcilers {
+		done, err := subReconciler.Reconcile(r.FoundationDBClusterReconciler, context, federatedCluster)
+		if !done {
+			if err != nil {
+				log.Error(err, "Error in federated reconciliation", "cluster", federatedCluster.Name)
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: subReconciler.RequeueAfter()}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers this reconciler with the controller manager.
+func (r *FederatedFoundationDBClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&fdbtypes.FederatedFoundationDBCluster{}).
+		Complete(r)
+}